@@ -0,0 +1,167 @@
+// Package filter implements regex-based allow/block lists for accounts,
+// instance domains, and hashtags/keywords, following the domains.txt /
+// nicknames.txt convention: one regex per line, blank lines and lines
+// starting with "#" ignored. Lists are loaded from files at startup and can
+// be hot-reloaded (e.g. on SIGHUP) without restarting the bot.
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// listConfig names the allow and block files for one filtered dimension.
+type listConfig struct {
+	AllowFile string `toml:"allow_file"`
+	BlockFile string `toml:"block_file"`
+}
+
+// Config configures the account, instance, and hashtag filter lists.
+type Config struct {
+	Accounts  listConfig `toml:"accounts"`
+	Instances listConfig `toml:"instances"`
+	Hashtags  listConfig `toml:"hashtags"`
+}
+
+// list holds the compiled allow and block patterns for one dimension.
+type list struct {
+	allow []*regexp.Regexp
+	block []*regexp.Regexp
+}
+
+// allows reports whether s passes the list: it must not match any block
+// pattern, and if any allow patterns are configured, it must match one of
+// them.
+func (l list) allows(s string) bool {
+	for _, re := range l.block {
+		if re.MatchString(s) {
+			return false
+		}
+	}
+	if len(l.allow) == 0 {
+		return true
+	}
+	for _, re := range l.allow {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter holds the compiled account, instance, and hashtag lists and can be
+// reloaded in place.
+type Filter struct {
+	mu sync.RWMutex
+
+	cfg       Config
+	accounts  list
+	instances list
+	hashtags  list
+}
+
+// Load compiles the lists named in cfg into a new Filter.
+func Load(cfg Config) (*Filter, error) {
+	f := &Filter{cfg: cfg}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reload recompiles every list from disk, replacing the Filter's state
+// atomically. Intended to be called on SIGHUP.
+func (f *Filter) Reload() error {
+	accounts, err := loadList(f.cfg.Accounts)
+	if err != nil {
+		return fmt.Errorf("accounts: %w", err)
+	}
+	instances, err := loadList(f.cfg.Instances)
+	if err != nil {
+		return fmt.Errorf("instances: %w", err)
+	}
+	hashtags, err := loadList(f.cfg.Hashtags)
+	if err != nil {
+		return fmt.Errorf("hashtags: %w", err)
+	}
+
+	f.mu.Lock()
+	f.accounts, f.instances, f.hashtags = accounts, instances, hashtags
+	f.mu.Unlock()
+	return nil
+}
+
+// AllowsAccount reports whether acct (a "user@instance" handle) passes the
+// account filter.
+func (f *Filter) AllowsAccount(acct string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.accounts.allows(acct)
+}
+
+// AllowsInstance reports whether domain passes the instance filter.
+func (f *Filter) AllowsInstance(domain string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.instances.allows(domain)
+}
+
+// AllowsContent reports whether content passes the hashtag/keyword filter.
+func (f *Filter) AllowsContent(content string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.hashtags.allows(content)
+}
+
+// loadList compiles the allow and block files named in cfg. A blank file
+// path means that side of the list is left empty.
+func loadList(cfg listConfig) (list, error) {
+	allow, err := readPatterns(cfg.AllowFile)
+	if err != nil {
+		return list{}, fmt.Errorf("allow file %q: %w", cfg.AllowFile, err)
+	}
+	block, err := readPatterns(cfg.BlockFile)
+	if err != nil {
+		return list{}, fmt.Errorf("block file %q: %w", cfg.BlockFile, err)
+	}
+	return list{allow: allow, block: block}, nil
+}
+
+// readPatterns reads one regex per line from path, ignoring blank lines and
+// "#" comments. A blank path returns no patterns.
+func readPatterns(path string) ([]*regexp.Regexp, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []*regexp.Regexp
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", line, err)
+		}
+		patterns = append(patterns, re)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
@@ -0,0 +1,115 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeList(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "list.txt")
+	content := ""
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestListAllowsEverythingByDefault(t *testing.T) {
+	var l list
+	if !l.allows("anything") {
+		t.Fatal("expected an empty list to allow everything")
+	}
+}
+
+func TestListBlockTakesPrecedenceOverAllow(t *testing.T) {
+	f, err := Load(Config{
+		Accounts: listConfig{
+			AllowFile: writeList(t, `.*`),
+			BlockFile: writeList(t, `^spammer@`),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if f.AllowsAccount("spammer@bad.social") {
+		t.Fatal("expected a blocked account to be denied even though it matches the allow list")
+	}
+	if !f.AllowsAccount("alice@good.social") {
+		t.Fatal("expected a non-blocked account matching the allow list to be allowed")
+	}
+}
+
+func TestListAllowlistRestrictsToMatches(t *testing.T) {
+	f, err := Load(Config{
+		Instances: listConfig{
+			AllowFile: writeList(t, `^good\.social$`),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if !f.AllowsInstance("good.social") {
+		t.Fatal("expected good.social to match the allowlist")
+	}
+	if f.AllowsInstance("other.social") {
+		t.Fatal("expected an instance not matching the allowlist to be denied")
+	}
+}
+
+func TestReadPatternsSkipsBlankLinesAndComments(t *testing.T) {
+	path := writeList(t, "", "# a comment", "  ", "^keep$")
+	patterns, err := readPatterns(path)
+	if err != nil {
+		t.Fatalf("readPatterns: %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].String() != "^keep$" {
+		t.Fatalf("readPatterns = %v, want a single ^keep$ pattern", patterns)
+	}
+}
+
+func TestReadPatternsBlankPath(t *testing.T) {
+	patterns, err := readPatterns("")
+	if err != nil || patterns != nil {
+		t.Fatalf("readPatterns(\"\") = %v, %v, want nil, nil", patterns, err)
+	}
+}
+
+func TestReadPatternsMissingFile(t *testing.T) {
+	patterns, err := readPatterns(filepath.Join(t.TempDir(), "missing.txt"))
+	if err != nil || patterns != nil {
+		t.Fatalf("readPatterns(missing) = %v, %v, want nil, nil", patterns, err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	blockFile := writeList(t, `^blocked$`)
+
+	f, err := Load(Config{Hashtags: listConfig{BlockFile: blockFile}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !f.AllowsContent("fine") {
+		t.Fatal("expected non-blocked content to be allowed")
+	}
+
+	if err := os.WriteFile(blockFile, []byte("fine\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := f.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if !f.AllowsContent("blocked") {
+		t.Fatal("expected the old block pattern to be gone after Reload")
+	}
+	if f.AllowsContent("fine") {
+		t.Fatal("expected the new block pattern to take effect after Reload")
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/abadojack/whatlanggo"
+	"github.com/mattn/go-mastodon"
+)
+
+// detectLanguage picks the ISO 639-1 language code to summarize in: an
+// operator override takes precedence, then the status's own Language field,
+// falling back to lightweight detection on its text when neither is set.
+func detectLanguage(status *mastodon.Status, content string) string {
+	if config.Summary.ForceLanguage != "" {
+		return config.Summary.ForceLanguage
+	}
+	if status.Language != "" {
+		return status.Language
+	}
+	return whatlanggo.Detect(content).Lang.Iso6391()
+}
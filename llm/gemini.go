@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// Gemini talks to Google's Gemini API.
+type Gemini struct {
+	model *genai.GenerativeModel
+}
+
+// NewGemini creates a Gemini-backed LLM using the given API key.
+func NewGemini(ctx context.Context, apiKey string) (*Gemini, error) {
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, err
+	}
+	return &Gemini{model: client.GenerativeModel("gemini-1.5-flash")}, nil
+}
+
+// Completion implements LLM.
+func (g *Gemini) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	g.model.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(systemPrompt)}}
+
+	resp, err := g.model.GenerateContent(ctx, genai.Text(userPrompt))
+	if err != nil {
+		return "", err
+	}
+	return responseText(resp), nil
+}
+
+// CompletionWithMedia implements LLM, passing each image to Gemini as a
+// genai.ImageData part alongside the text prompt.
+func (g *Gemini) CompletionWithMedia(ctx context.Context, systemPrompt, userPrompt string, media []Media) (string, error) {
+	g.model.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(systemPrompt)}}
+
+	parts := make([]genai.Part, 0, len(media)+1)
+	for _, m := range media {
+		parts = append(parts, genai.ImageData(strings.TrimPrefix(m.MIMEType, "image/"), m.Data))
+	}
+	parts = append(parts, genai.Text(userPrompt))
+
+	resp, err := g.model.GenerateContent(ctx, parts...)
+	if err != nil {
+		return "", err
+	}
+	return responseText(resp), nil
+}
+
+func (g *Gemini) GetSystemRole() string    { return "system" }
+func (g *Gemini) GetUserRole() string      { return "user" }
+func (g *Gemini) GetAssistantRole() string { return "model" }
+
+// responseText flattens the parts of a Gemini response into plain text.
+func responseText(resp *genai.GenerateContentResponse) string {
+	var response string
+	for _, cand := range resp.Candidates {
+		if cand.Content != nil {
+			for _, part := range cand.Content.Parts {
+				response += fmt.Sprintf("%v", part)
+			}
+		}
+	}
+	return response
+}
@@ -0,0 +1,70 @@
+// Package llm abstracts over the different chat-completion backends the bot
+// can summarize with, so the rest of the code never has to know whether it's
+// talking to Gemini, Ollama, or an OpenAI-compatible endpoint.
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Media is image attachment data to accompany a prompt.
+type Media struct {
+	MIMEType string
+	Data     []byte
+}
+
+// LLM is implemented by each provider backend the bot can generate
+// summaries with.
+type LLM interface {
+	// Completion sends a system prompt and a user prompt to the provider and
+	// returns the generated text.
+	Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error)
+
+	// CompletionWithMedia behaves like Completion but additionally sends
+	// media alongside the prompt. Providers without vision support ignore
+	// media and fall back to a text-only completion.
+	CompletionWithMedia(ctx context.Context, systemPrompt, userPrompt string, media []Media) (string, error)
+
+	// GetSystemRole, GetUserRole, and GetAssistantRole return the role names
+	// this provider expects in a chat-style message (e.g. "model" instead of
+	// "assistant" for Gemini), so callers can build provider-agnostic prompts.
+	GetSystemRole() string
+	GetUserRole() string
+	GetAssistantRole() string
+}
+
+// Config selects and configures the provider to use.
+type Config struct {
+	Provider   string `toml:"provider"`
+	Multimodal bool   `toml:"multimodal"`
+
+	Gemini struct {
+		APIKey string `toml:"api_key"`
+	} `toml:"gemini"`
+
+	Ollama struct {
+		Host  string `toml:"host"`
+		Model string `toml:"model"`
+	} `toml:"ollama"`
+
+	OpenAI struct {
+		BaseURL string `toml:"base_url"`
+		APIKey  string `toml:"api_key"`
+		Model   string `toml:"model"`
+	} `toml:"openai"`
+}
+
+// New builds the LLM backend selected by cfg.Provider.
+func New(ctx context.Context, cfg Config) (LLM, error) {
+	switch cfg.Provider {
+	case "gemini":
+		return NewGemini(ctx, cfg.Gemini.APIKey)
+	case "ollama":
+		return NewOllama(cfg.Ollama.Host, cfg.Ollama.Model), nil
+	case "openai":
+		return NewOpenAI(cfg.OpenAI.BaseURL, cfg.OpenAI.APIKey, cfg.OpenAI.Model), nil
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider: %s", cfg.Provider)
+	}
+}
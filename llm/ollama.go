@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// defaultOllamaHost is used when no host is configured.
+const defaultOllamaHost = "http://localhost:11434"
+
+// Ollama talks to a local (or remote) Ollama server over its HTTP API,
+// rather than shelling out to the ollama binary.
+type Ollama struct {
+	host   string
+	model  string
+	client *http.Client
+}
+
+// NewOllama creates an Ollama-backed LLM. host defaults to defaultOllamaHost.
+func NewOllama(host, model string) *Ollama {
+	if host == "" {
+		host = defaultOllamaHost
+	}
+	return &Ollama{host: host, model: model, client: http.DefaultClient}
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Message ollamaMessage `json:"message"`
+}
+
+// Completion implements LLM.
+func (o *Ollama) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	reqBody, err := json.Marshal(ollamaChatRequest{
+		Model: o.model,
+		Messages: []ollamaMessage{
+			{Role: o.GetSystemRole(), Content: systemPrompt},
+			{Role: o.GetUserRole(), Content: userPrompt},
+		},
+		Stream: false,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.host+"/api/chat", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ollama: unexpected status %s", resp.Status)
+	}
+
+	var chatResp ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", err
+	}
+	return chatResp.Message.Content, nil
+}
+
+// CompletionWithMedia implements LLM. Ollama's /api/chat has no vision
+// support here, so media is ignored and this falls back to a text-only
+// completion.
+func (o *Ollama) CompletionWithMedia(ctx context.Context, systemPrompt, userPrompt string, media []Media) (string, error) {
+	return o.Completion(ctx, systemPrompt, userPrompt)
+}
+
+func (o *Ollama) GetSystemRole() string    { return "system" }
+func (o *Ollama) GetUserRole() string      { return "user" }
+func (o *Ollama) GetAssistantRole() string { return "assistant" }
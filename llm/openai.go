@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// defaultOpenAIBaseURL is used when no base URL is configured.
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAI talks to any OpenAI-compatible chat-completions endpoint, so
+// operators can point it at LM Studio, llama.cpp, Groq, OpenRouter, etc. by
+// changing the base URL.
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAI creates an OpenAI-compatible LLM. baseURL defaults to
+// defaultOpenAIBaseURL.
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAI{baseURL: baseURL, apiKey: apiKey, model: model, client: http.DefaultClient}
+}
+
+// openAIMessage's Content is a string for plain text messages, or a
+// []openAIContentPart for messages that include image parts.
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// Completion implements LLM.
+func (o *OpenAI) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return o.chat(ctx, openAIMessage{Role: o.GetSystemRole(), Content: systemPrompt}, openAIMessage{Role: o.GetUserRole(), Content: userPrompt})
+}
+
+// CompletionWithMedia implements LLM, sending each image as a base64 data
+// URL alongside the text prompt, per the OpenAI vision message format.
+func (o *OpenAI) CompletionWithMedia(ctx context.Context, systemPrompt, userPrompt string, media []Media) (string, error) {
+	if len(media) == 0 {
+		return o.Completion(ctx, systemPrompt, userPrompt)
+	}
+
+	parts := make([]openAIContentPart, 0, len(media)+1)
+	parts = append(parts, openAIContentPart{Type: "text", Text: userPrompt})
+	for _, m := range media {
+		dataURL := fmt.Sprintf("data:%s;base64,%s", m.MIMEType, base64.StdEncoding.EncodeToString(m.Data))
+		parts = append(parts, openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{URL: dataURL}})
+	}
+
+	return o.chat(ctx, openAIMessage{Role: o.GetSystemRole(), Content: systemPrompt}, openAIMessage{Role: o.GetUserRole(), Content: parts})
+}
+
+// chat sends messages to the configured chat-completions endpoint and
+// returns the first choice's text.
+func (o *OpenAI) chat(ctx context.Context, messages ...openAIMessage) (string, error) {
+	reqBody, err := json.Marshal(openAIChatRequest{Model: o.model, Messages: messages})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("openai: unexpected status %s", resp.Status)
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", err
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("openai: empty response")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+func (o *OpenAI) GetSystemRole() string    { return "system" }
+func (o *OpenAI) GetUserRole() string      { return "user" }
+func (o *OpenAI) GetAssistantRole() string { return "assistant" }
@@ -1,21 +1,25 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
+	"os/signal"
 	"regexp"
 	"strings"
+	"syscall"
+	"time"
 	"unicode"
 
 	"github.com/BurntSushi/toml"
-	"github.com/google/generative-ai-go/genai"
 	"github.com/mattn/go-mastodon"
 	"golang.org/x/net/html"
-	"google.golang.org/api/option"
+
+	"github.com/micr0-dev/tldr-bot/filter"
+	"github.com/micr0-dev/tldr-bot/llm"
+	"github.com/micr0-dev/tldr-bot/ratelimit"
+	"github.com/micr0-dev/tldr-bot/store"
 )
 
 type Config struct {
@@ -24,17 +28,26 @@ type Config struct {
 		ClientSecret   string `toml:"client_secret"`
 		AccessToken    string `toml:"access_token"`
 	} `toml:"server"`
-	LLM struct {
-		Provider    string `toml:"provider"`
-		OllamaModel string `toml:"ollama_model"`
-	} `toml:"llm"`
-	Gemini struct {
-		APIKey string `toml:"api_key"`
-	} `toml:"gemini"`
+	Store struct {
+		Path string `toml:"path"`
+	} `toml:"store"`
+	Summary struct {
+		ForceLanguage string `toml:"force_language"`
+	} `toml:"summary"`
+	LLM       llm.Config       `toml:"llm"`
+	Filters   filter.Config    `toml:"filters"`
+	RateLimit ratelimit.Config `toml:"ratelimit"`
 }
 
+// followerReconcileInterval controls how often reconcileFollowers re-syncs
+// the followers table against the Mastodon API.
+const followerReconcileInterval = 1 * time.Hour
+
 var config Config
-var model *genai.GenerativeModel
+var provider llm.LLM
+var db *store.Store
+var flt *filter.Filter
+var limiter *ratelimit.Limiter
 var ctx context.Context
 
 func main() {
@@ -50,10 +63,43 @@ func main() {
 		AccessToken:  config.Server.AccessToken,
 	})
 
-	// Set up AI model
-	if err := SetupModel(config.Gemini.APIKey); err != nil {
-		log.Fatalf("Error setting up AI model: %v", err)
+	// Set up the LLM provider
+	var err error
+	provider, err = llm.New(ctx, config.LLM)
+	if err != nil {
+		log.Fatalf("Error setting up LLM provider: %v", err)
+	}
+
+	// Set up the persistent state store
+	storePath := config.Store.Path
+	if storePath == "" {
+		storePath = "tldr-bot.db"
 	}
+	db, err = store.Open(storePath)
+	if err != nil {
+		log.Fatalf("Error opening store: %v", err)
+	}
+	defer db.Close()
+
+	// Set up the account/instance/hashtag filter lists, reloadable on SIGHUP
+	flt, err = filter.Load(config.Filters)
+	if err != nil {
+		log.Fatalf("Error loading filters: %v", err)
+	}
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			if err := flt.Reload(); err != nil {
+				log.Printf("Error reloading filters: %v", err)
+				continue
+			}
+			fmt.Println("Reloaded filters")
+		}
+	}()
+
+	// Set up the global and per-account rate limiters
+	limiter = ratelimit.New(config.RateLimit)
 
 	ws := c.NewWSClient()
 
@@ -64,6 +110,17 @@ func main() {
 	}
 	fmt.Println("Thread Summarizer Bot is running...")
 
+	// Periodically reconcile the followers table, since unfollows don't
+	// generate a streaming event we can react to.
+	reconcileFollowers(c)
+	go func() {
+		ticker := time.NewTicker(followerReconcileInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			reconcileFollowers(c)
+		}
+	}()
+
 	// Event loop to listen for mentions and follows
 	for event := range events {
 		switch e := event.(type) {
@@ -77,34 +134,85 @@ func main() {
 				if e.Notification.Account.Bot {
 					break
 				}
-				handleFollowBack(c, e.Notification.Account.ID)
+				handleFollowBack(c, e.Notification.Account)
 			}
 		case *mastodon.UpdateEvent:
 			if e.Status.Account.Bot {
 				break
 			}
 			checkForLongPost(c, e.Status)
+		case *mastodon.UpdateEditEvent:
+			handleEdit(c, e.Status)
 		}
 	}
 }
 
-// SetupModel initializes the Gemini AI model
-func SetupModel(apiKey string) error {
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+// handleFollowBack follows back new followers, skipping accounts already
+// recorded in the followers table so restarts don't re-send a follow request.
+func handleFollowBack(c *mastodon.Client, account mastodon.Account) {
+	following, err := db.IsFollowing(string(account.ID))
 	if err != nil {
-		return err
+		log.Printf("Error checking follow state for %s: %v", account.Acct, err)
+	}
+	if following {
+		return
 	}
-	model = client.GenerativeModel("gemini-1.5-flash")
-	return nil
+
+	if _, err := c.AccountFollow(ctx, account.ID); err != nil {
+		log.Printf("Error following back: %v", err)
+		return
+	}
+
+	if err := db.RecordFollow(string(account.ID), account.Acct); err != nil {
+		log.Printf("Error recording follow for %s: %v", account.Acct, err)
+	}
+
+	fmt.Printf("Followed back user: %s\n", account.Acct)
 }
 
-// handleFollowBack follows back new followers
-func handleFollowBack(c *mastodon.Client, userID mastodon.ID) {
-	_, err := c.AccountFollow(ctx, userID)
+// reconcileFollowers re-syncs the followers table against the Mastodon API.
+// Unlike follows, unfollows don't arrive as a streaming event, so this is
+// the only way handleFollowBack's idempotency stays accurate over time.
+func reconcileFollowers(c *mastodon.Client) {
+	me, err := c.GetAccountCurrentUser(ctx)
 	if err != nil {
-		log.Printf("Error following back: %v", err)
-	} else {
-		fmt.Printf("Followed back user: %s\n", userID)
+		log.Printf("Error reconciling followers: %v", err)
+		return
+	}
+
+	var current []*mastodon.Account
+	pg := &mastodon.Pagination{}
+	for {
+		page, err := c.GetAccountFollowers(ctx, me.ID, pg)
+		if err != nil {
+			log.Printf("Error reconciling followers: %v", err)
+			return
+		}
+		current = append(current, page...)
+		if pg.MaxID == "" {
+			break
+		}
+	}
+
+	seen := make(map[string]bool, len(current))
+	for _, account := range current {
+		seen[string(account.ID)] = true
+		if err := db.RecordFollow(string(account.ID), account.Acct); err != nil {
+			log.Printf("Error recording follower %s: %v", account.Acct, err)
+		}
+	}
+
+	stored, err := db.Followers()
+	if err != nil {
+		log.Printf("Error listing stored followers: %v", err)
+		return
+	}
+	for _, follower := range stored {
+		if !seen[follower.AccountID] {
+			if err := db.RecordUnfollow(follower.AccountID); err != nil {
+				log.Printf("Error recording unfollow for %s: %v", follower.Acct, err)
+			}
+		}
 	}
 }
 
@@ -114,13 +222,63 @@ func handleMention(c *mastodon.Client, notification *mastodon.Notification) {
 		return
 	}
 
-	thread, err := fetchThread(c, notification.Status)
+	optedOut, err := db.IsOptedOut(notification.Account.Acct)
+	if err != nil {
+		log.Printf("Error checking opt-out state: %v", err)
+	}
+
+	mentionText := strings.ToLower(extractTextFromHTML(notification.Status.Content))
+	if strings.Contains(mentionText, "optout") || strings.Contains(mentionText, "stop") {
+		if err := db.OptOut(notification.Account.Acct); err != nil {
+			log.Printf("Error recording opt-out: %v", err)
+			return
+		}
+		reply(c, notification.Status, fmt.Sprintf("@%s you've been opted out. I won't reply to your mentions or TL;DR your posts anymore.", notification.Account.Acct), true, "")
+		return
+	}
+
+	if optedOut {
+		return
+	}
+
+	alreadySummarized, err := db.HasSummarized(string(notification.Status.ID))
+	if err != nil {
+		log.Printf("Error checking dedup state: %v", err)
+	}
+	if alreadySummarized {
+		return
+	}
+
+	if allowed, wait := limiter.AllowAccount(notification.Account.Acct); !allowed {
+		if limiter.AllowGlobal() {
+			minutes := int(wait/time.Minute) + 1
+			reply(c, notification.Status, fmt.Sprintf("@%s rate limited, try again in %dm", notification.Account.Acct, minutes), true, "")
+		}
+		return
+	}
+	if !limiter.AllowGlobal() {
+		log.Printf("Global rate limit hit, dropping mention from %s", notification.Account.Acct)
+		return
+	}
+
+	thread, rootStatus, attachments, err := fetchThread(c, notification.Status)
 	if err != nil {
 		log.Printf("Error fetching thread: %v", err)
 		return
 	}
 
-	summary, err := summarizeThread(thread, false)
+	if !allowAccount(rootStatus.Account.Acct) {
+		return
+	}
+
+	var media []llm.Media
+	if config.LLM.Multimodal {
+		media = fetchMedia(attachments)
+	}
+
+	language := detectLanguage(rootStatus, extractTextFromHTML(rootStatus.Content))
+
+	summary, err := summarizeThread(thread, false, media, language)
 	if err != nil {
 		log.Printf("Error summarizing thread: %v", err)
 		summary = "uh oh, something went wrong. can't summarize this thread.\n" + err.Error()
@@ -130,37 +288,87 @@ func handleMention(c *mastodon.Client, notification *mastodon.Notification) {
 
 	response := fmt.Sprintf("@%s TL;DR: %s", notification.Account.Acct, summary)
 
-	visibility := notification.Status.Visibility
-	if visibility == "public" {
+	replyStatus, err := reply(c, notification.Status, response, true, language)
+	if err != nil {
+		log.Printf("Error posting summary: %v", err)
+		return
+	}
+	fmt.Printf("Posted summary: %s\n", response)
+
+	if err := db.RecordSummary(string(notification.Status.ID), string(replyStatus.ID)); err != nil {
+		log.Printf("Error recording summary: %v", err)
+	}
+}
+
+// reply posts response as a reply to source, carrying over its content
+// warning and setting its Language field. If forceUnlisted is set, a public
+// source is replied to unlisted (used for mention replies, which would
+// otherwise spam the requester's public timeline).
+func reply(c *mastodon.Client, source *mastodon.Status, response string, forceUnlisted bool, language string) (*mastodon.Status, error) {
+	visibility := source.Visibility
+	if forceUnlisted && visibility == "public" {
 		visibility = "unlisted"
 	}
 
-	// Prepare the content warning for the reply
-	contentWarning := notification.Status.SpoilerText
+	contentWarning := source.SpoilerText
 	if contentWarning != "" && !strings.HasPrefix(contentWarning, "re:") {
 		contentWarning = "re: " + contentWarning
 	}
 
-	_, err = c.PostStatus(ctx, &mastodon.Toot{
+	return c.PostStatus(ctx, &mastodon.Toot{
 		Status:      response,
-		InReplyToID: notification.Status.ID,
+		InReplyToID: source.ID,
 		Visibility:  visibility,
 		SpoilerText: contentWarning,
+		Language:    language,
 	})
-	if err != nil {
-		log.Printf("Error posting summary: %v", err)
-	} else {
-		fmt.Printf("Posted summary: %s\n", response)
-	}
 }
 
 // checkForLongPost checks if a post is long and needs a TL;DR
 func checkForLongPost(c *mastodon.Client, status *mastodon.Status) {
+	optedOut, err := db.IsOptedOut(status.Account.Acct)
+	if err != nil {
+		log.Printf("Error checking opt-out state: %v", err)
+	}
+	if optedOut {
+		return
+	}
+
+	alreadySummarized, err := db.HasSummarized(string(status.ID))
+	if err != nil {
+		log.Printf("Error checking dedup state: %v", err)
+	}
+	if alreadySummarized {
+		return
+	}
+
+	if !allowAccount(status.Account.Acct) {
+		return
+	}
+
 	content := extractTextFromHTML(status.Content)
 	wordCount := countWords(content)
 
-	if wordCount > 200 && !strings.Contains(strings.ToLower(content), "tl;dr") {
-		summary, err := summarizeThread(content, true)
+	if wordCount > 200 && !strings.Contains(strings.ToLower(content), "tl;dr") && flt.AllowsContent(content) {
+		if allowed, _ := limiter.AllowAccount(status.Account.Acct); !allowed {
+			return
+		}
+		if !limiter.AllowGlobal() {
+			return
+		}
+
+		summaryContent := content
+		var media []llm.Media
+		if config.LLM.Multimodal {
+			media = fetchMedia(status.MediaAttachments)
+			if alt := altText(status.MediaAttachments); alt != "" {
+				summaryContent += "\n" + alt
+			}
+		}
+
+		language := detectLanguage(status, content)
+
+		summary, err := summarizeThread(summaryContent, true, media, language)
 		if err != nil {
 			log.Printf("Error generating TL;DR: %v", err)
 			return
@@ -170,23 +378,79 @@ func checkForLongPost(c *mastodon.Client, status *mastodon.Status) {
 
 		response := fmt.Sprintf("@%s TL;DR: %s", status.Account.Acct, summary)
 
-		// Prepare the content warning for the reply
-		contentWarning := status.SpoilerText
-		if contentWarning != "" && !strings.HasPrefix(contentWarning, "re:") {
-			contentWarning = "re: " + contentWarning
-		}
-
-		_, err = c.PostStatus(ctx, &mastodon.Toot{
-			Status:      response,
-			InReplyToID: status.ID,
-			Visibility:  status.Visibility,
-			SpoilerText: contentWarning,
-		})
+		replyStatus, err := reply(c, status, response, false, language)
 		if err != nil {
 			log.Printf("Error posting TL;DR: %v", err)
-		} else {
-			fmt.Printf("Posted TL;DR for user %s\n", status.Account.Acct)
+			return
+		}
+		fmt.Printf("Posted TL;DR for user %s\n", status.Account.Acct)
+
+		if err := db.RecordSummary(string(status.ID), string(replyStatus.ID)); err != nil {
+			log.Printf("Error recording summary: %v", err)
+		}
+	}
+}
+
+// handleEdit re-summarizes a status after it's been edited and updates the
+// bot's existing TL;DR reply in place, rather than posting a duplicate. If
+// the edited status has dropped below the 200-word threshold, the outdated
+// TL;DR is deleted instead.
+func handleEdit(c *mastodon.Client, status *mastodon.Status) {
+	if status.Account.Bot || status.EditedAt.IsZero() {
+		return
+	}
+
+	replyID, ok, err := db.GetSummaryReply(string(status.ID))
+	if err != nil {
+		log.Printf("Error looking up stored summary: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	if allowed, _ := limiter.AllowAccount(status.Account.Acct); !allowed {
+		return
+	}
+	if !limiter.AllowGlobal() {
+		return
+	}
+
+	content := extractTextFromHTML(status.Content)
+
+	if countWords(content) <= 200 {
+		if err := c.DeleteStatus(ctx, mastodon.ID(replyID)); err != nil {
+			log.Printf("Error deleting outdated TL;DR: %v", err)
+			return
+		}
+		if err := db.DeleteSummary(string(status.ID)); err != nil {
+			log.Printf("Error clearing stored summary: %v", err)
 		}
+		return
+	}
+
+	summaryContent := content
+	var media []llm.Media
+	if config.LLM.Multimodal {
+		media = fetchMedia(status.MediaAttachments)
+		if alt := altText(status.MediaAttachments); alt != "" {
+			summaryContent += "\n" + alt
+		}
+	}
+
+	language := detectLanguage(status, content)
+
+	summary, err := summarizeThread(summaryContent, true, media, language)
+	if err != nil {
+		log.Printf("Error re-summarizing edited status: %v", err)
+		return
+	}
+	summary = cleanResponse(summary)
+
+	response := fmt.Sprintf("@%s TL;DR: %s", status.Account.Acct, summary)
+
+	if _, err := c.UpdateStatus(ctx, &mastodon.Toot{Status: response, Language: language}, mastodon.ID(replyID)); err != nil {
+		log.Printf("Error updating TL;DR: %v", err)
 	}
 }
 
@@ -211,14 +475,23 @@ func cleanResponse(response string) string {
 	return response
 }
 
-// fetchThread gathers the entire thread up to the root post
-func fetchThread(c *mastodon.Client, status *mastodon.Status) (string, error) {
+// fetchThread gathers the entire thread up to the root post, returning the
+// joined thread text, the root post itself, and every media attachment
+// found along the way.
+func fetchThread(c *mastodon.Client, status *mastodon.Status) (string, *mastodon.Status, []mastodon.Attachment, error) {
 	var thread []string
+	var attachments []mastodon.Attachment
 	currentStatus := status
 
 	for currentStatus != nil {
 		content := extractTextFromHTML(currentStatus.Content)
+		if config.LLM.Multimodal {
+			if alt := altText(currentStatus.MediaAttachments); alt != "" {
+				content += "\n" + alt
+			}
+		}
 		thread = append([]string{fmt.Sprintf("%s: %s", currentStatus.Account.Username, content)}, thread...)
+		attachments = append(attachments, currentStatus.MediaAttachments...)
 
 		if currentStatus.InReplyToID == nil {
 			break
@@ -233,7 +506,28 @@ func fetchThread(c *mastodon.Client, status *mastodon.Status) (string, error) {
 		currentStatus = parentStatus
 	}
 
-	return strings.Join(thread, "\n\n"), nil
+	return strings.Join(thread, "\n\n"), currentStatus, attachments, nil
+}
+
+// allowAccount reports whether acct passes both the account and instance
+// filters.
+func allowAccount(acct string) bool {
+	if !flt.AllowsAccount(acct) {
+		return false
+	}
+	if domain := domainFromAcct(acct); domain != "" && !flt.AllowsInstance(domain) {
+		return false
+	}
+	return true
+}
+
+// domainFromAcct extracts the instance domain from a "user@instance"
+// handle. Local accounts (no "@") return an empty string.
+func domainFromAcct(acct string) string {
+	if i := strings.Index(acct, "@"); i != -1 {
+		return acct[i+1:]
+	}
+	return ""
 }
 
 // extractTextFromHTML extracts plain text from HTML content
@@ -257,56 +551,24 @@ func extractTextFromHTML(content string) string {
 	return extractText(doc)
 }
 
-// summarizeThread uses the AI model to summarize the thread
-func summarizeThread(thread string, isSinglePost bool) (string, error) {
-	var prompt string
+// summarizeThread uses the configured LLM provider to summarize the thread,
+// in the given ISO 639-1 language. When media is non-empty, it's passed
+// along so providers with vision support (e.g. Gemini) can factor images
+// into the summary.
+func summarizeThread(thread string, isSinglePost bool, media []llm.Media, language string) (string, error) {
+	systemPrompt := fmt.Sprintf("You write short, factual TL;DR summaries of Mastodon posts and conversations. Write the TL;DR in the language with ISO 639-1 code %q. Reply with just the TL;DR and nothing else.", language)
+
+	var userPrompt string
 	if !isSinglePost {
-		prompt = fmt.Sprintf("Write a TL;DR summary for this conversation. Reply with just the TL;DR and nothing else:\n%s", thread)
+		userPrompt = fmt.Sprintf("Write a TL;DR summary for this conversation:\n%s", thread)
 	} else {
-		prompt = fmt.Sprintf("Write a TL;DR summary for this post. Refer to the Original poster as OP. Reply with just the TL;DR and nothing else:\n%s", thread)
-	}
-	switch config.LLM.Provider {
-	case "gemini":
-		return generateWithGemini(prompt)
-	case "ollama":
-		return generateWithOllama(prompt)
-	default:
-		return "", fmt.Errorf("unsupported LLM provider: %s", config.LLM.Provider)
+		userPrompt = fmt.Sprintf("Write a TL;DR summary for this post. Refer to the original poster as OP:\n%s", thread)
 	}
-}
 
-// generateWithGemini sends a prompt to the Gemini model
-func generateWithGemini(prompt string) (string, error) {
-	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
-	if err != nil {
-		return "", err
+	if len(media) > 0 {
+		return provider.CompletionWithMedia(ctx, systemPrompt, userPrompt, media)
 	}
-	return getResponse(resp), nil
-}
-
-// generateWithOllama runs the Ollama command
-func generateWithOllama(prompt string) (string, error) {
-	cmd := exec.Command("ollama", "run", config.LLM.OllamaModel, prompt)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-
-	if err := cmd.Run(); err != nil {
-		return "", err
-	}
-	return out.String(), nil
-}
-
-// getResponse extracts the response from the AI model
-func getResponse(resp *genai.GenerateContentResponse) string {
-	var response string
-	for _, cand := range resp.Candidates {
-		if cand.Content != nil {
-			for _, part := range cand.Content.Parts {
-				response += fmt.Sprintf("%v", part)
-			}
-		}
-	}
-	return response
+	return provider.Completion(ctx, systemPrompt, userPrompt)
 }
 
 // countWords counts the words in a string
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mattn/go-mastodon"
+
+	"github.com/micr0-dev/tldr-bot/llm"
+)
+
+const (
+	// maxMediaPerStatus caps how many attachments from a single status are
+	// sent to the LLM, to keep prompts (and provider bills) bounded.
+	maxMediaPerStatus = 4
+	// maxMediaBytes caps the size of any one downloaded attachment.
+	maxMediaBytes = 5 * 1024 * 1024
+)
+
+// fetchMedia downloads up to maxMediaPerStatus image attachments, sniffing
+// their MIME type and skipping anything too large or non-image. Attachments
+// that already have alt text are skipped entirely, since altText already
+// gives the LLM a description without spending a download or a vision call
+// on them. Video attachments aren't downloaded either way.
+func fetchMedia(attachments []mastodon.Attachment) []llm.Media {
+	var media []llm.Media
+	for _, a := range attachments {
+		if len(media) >= maxMediaPerStatus {
+			break
+		}
+		if a.Type != "image" || a.Description != "" {
+			continue
+		}
+
+		data, mimeType, err := downloadImage(a.URL)
+		if err != nil {
+			log.Printf("Error downloading attachment %s: %v", a.URL, err)
+			continue
+		}
+
+		media = append(media, llm.Media{MIMEType: mimeType, Data: data})
+	}
+	return media
+}
+
+// altText collects existing alt-text descriptions from attachments, one per
+// line, so already-described images can be folded into the prompt as plain
+// text instead of spending a download and a vision call on them.
+func altText(attachments []mastodon.Attachment) string {
+	var lines []string
+	for _, a := range attachments {
+		if a.Description != "" {
+			lines = append(lines, fmt.Sprintf("[image: %s]", a.Description))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// downloadImage fetches url, sniffing its content type and enforcing
+// maxMediaBytes.
+func downloadImage(url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxMediaBytes {
+		return nil, "", fmt.Errorf("attachment exceeds %d byte cap", maxMediaBytes)
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, "", fmt.Errorf("unsupported content type %q", mimeType)
+	}
+
+	return data, mimeType, nil
+}
@@ -0,0 +1,134 @@
+// Package ratelimit provides token-bucket rate limiting for outbound
+// Mastodon posts and LLM calls, both globally and per requesting account, so
+// a burst of activity can't blow through an instance's or provider's API
+// quota and a single user can't drain the bot.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultGlobalRatePerSecond = 1
+	defaultGlobalBurst         = 5
+	defaultPerAccountPerHour   = 10
+	defaultPerAccountBurst     = 3
+	defaultEvictAfterMinutes   = 24 * 60
+)
+
+// Config configures the global and per-account limiters.
+type Config struct {
+	Global struct {
+		RatePerSecond float64 `toml:"rate_per_second"`
+		Burst         int     `toml:"burst"`
+	} `toml:"global"`
+
+	PerAccount struct {
+		PerHour int `toml:"per_hour"`
+		Burst   int `toml:"burst"`
+	} `toml:"per_account"`
+
+	// EvictAfterMinutes is how long an idle per-account bucket is kept
+	// before it's dropped, so long-running bots don't leak memory.
+	EvictAfterMinutes int `toml:"evict_after_minutes"`
+}
+
+// bucket is one account's limiter plus bookkeeping for eviction.
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter enforces a global rate limit and a per-account rate limit.
+type Limiter struct {
+	global *rate.Limiter
+
+	mu              sync.Mutex
+	perAccount      map[string]*bucket
+	perAccountRate  rate.Limit
+	perAccountBurst int
+	evictAfter      time.Duration
+}
+
+// New builds a Limiter from cfg, filling in sane defaults for anything left
+// unset.
+func New(cfg Config) *Limiter {
+	ratePerSecond := cfg.Global.RatePerSecond
+	if ratePerSecond <= 0 {
+		ratePerSecond = defaultGlobalRatePerSecond
+	}
+	globalBurst := cfg.Global.Burst
+	if globalBurst <= 0 {
+		globalBurst = defaultGlobalBurst
+	}
+
+	perHour := cfg.PerAccount.PerHour
+	if perHour <= 0 {
+		perHour = defaultPerAccountPerHour
+	}
+	perAccountBurst := cfg.PerAccount.Burst
+	if perAccountBurst <= 0 {
+		perAccountBurst = defaultPerAccountBurst
+	}
+
+	evictAfterMinutes := cfg.EvictAfterMinutes
+	if evictAfterMinutes <= 0 {
+		evictAfterMinutes = defaultEvictAfterMinutes
+	}
+
+	return &Limiter{
+		global:          rate.NewLimiter(rate.Limit(ratePerSecond), globalBurst),
+		perAccount:      make(map[string]*bucket),
+		perAccountRate:  rate.Every(time.Hour / time.Duration(perHour)),
+		perAccountBurst: perAccountBurst,
+		evictAfter:      time.Duration(evictAfterMinutes) * time.Minute,
+	}
+}
+
+// AllowGlobal reports whether a global-budget action (an outbound post or
+// LLM call) is allowed right now.
+func (l *Limiter) AllowGlobal() bool {
+	return l.global.Allow()
+}
+
+// AllowAccount reports whether acct may make another request right now. If
+// not, it also returns how long the requester should wait before retrying.
+// acct's bucket is created on first use and its last-seen time refreshed on
+// every call.
+func (l *Limiter) AllowAccount(acct string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.evictLocked()
+
+	b, ok := l.perAccount[acct]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.perAccountRate, l.perAccountBurst)}
+		l.perAccount[acct] = b
+	}
+	b.lastSeen = time.Now()
+
+	r := b.limiter.Reserve()
+	if !r.OK() {
+		return false, 0
+	}
+	if delay := r.Delay(); delay > 0 {
+		r.Cancel()
+		return false, delay
+	}
+	return true, 0
+}
+
+// evictLocked drops buckets idle for longer than evictAfter. Called with mu
+// held.
+func (l *Limiter) evictLocked() {
+	cutoff := time.Now().Add(-l.evictAfter)
+	for acct, b := range l.perAccount {
+		if b.lastSeen.Before(cutoff) {
+			delete(l.perAccount, acct)
+		}
+	}
+}
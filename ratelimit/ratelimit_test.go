@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowAccountBurstThenDenyWithWait(t *testing.T) {
+	cfg := Config{}
+	cfg.PerAccount.PerHour = 1
+	cfg.PerAccount.Burst = 2
+	l := New(cfg)
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := l.AllowAccount("alice"); !allowed {
+			t.Fatalf("expected request %d within burst to be allowed", i)
+		}
+	}
+
+	allowed, wait := l.AllowAccount("alice")
+	if allowed {
+		t.Fatal("expected request beyond burst to be denied")
+	}
+	if wait <= 0 {
+		t.Fatal("expected a positive wait duration when denied")
+	}
+}
+
+func TestAllowAccountIsPerAccount(t *testing.T) {
+	cfg := Config{}
+	cfg.PerAccount.PerHour = 1
+	cfg.PerAccount.Burst = 1
+	l := New(cfg)
+
+	if allowed, _ := l.AllowAccount("alice"); !allowed {
+		t.Fatal("expected alice's first request to be allowed")
+	}
+	if allowed, _ := l.AllowAccount("alice"); allowed {
+		t.Fatal("expected alice's second request to be denied")
+	}
+	if allowed, _ := l.AllowAccount("bob"); !allowed {
+		t.Fatal("expected bob to have his own independent bucket")
+	}
+}
+
+func TestAllowGlobalBurst(t *testing.T) {
+	cfg := Config{}
+	cfg.Global.RatePerSecond = 1
+	cfg.Global.Burst = 2
+	l := New(cfg)
+
+	if !l.AllowGlobal() || !l.AllowGlobal() {
+		t.Fatal("expected requests within the global burst to be allowed")
+	}
+	if l.AllowGlobal() {
+		t.Fatal("expected a request beyond the global burst to be denied")
+	}
+}
+
+func TestEvictLockedDropsIdleBuckets(t *testing.T) {
+	cfg := Config{}
+	cfg.EvictAfterMinutes = 1
+	l := New(cfg)
+
+	if allowed, _ := l.AllowAccount("alice"); !allowed {
+		t.Fatal("expected alice's first request to be allowed")
+	}
+	l.perAccount["alice"].lastSeen = time.Now().Add(-2 * time.Minute)
+
+	l.mu.Lock()
+	l.evictLocked()
+	_, stillPresent := l.perAccount["alice"]
+	l.mu.Unlock()
+
+	if stillPresent {
+		t.Fatal("expected an idle-past-evictAfter bucket to be dropped")
+	}
+}
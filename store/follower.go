@@ -0,0 +1,32 @@
+package store
+
+import "gorm.io/gorm/clause"
+
+// IsFollowing reports whether accountID currently follows the bot.
+func (s *Store) IsFollowing(accountID string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&Follower{}).Where("account_id = ?", accountID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RecordFollow remembers that the account identified by accountID (acct)
+// now follows the bot.
+func (s *Store) RecordFollow(accountID, acct string) error {
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&Follower{AccountID: accountID, Acct: acct}).Error
+}
+
+// RecordUnfollow removes accountID from the followers table.
+func (s *Store) RecordUnfollow(accountID string) error {
+	return s.db.Where("account_id = ?", accountID).Delete(&Follower{}).Error
+}
+
+// Followers returns every account currently recorded as following the bot.
+func (s *Store) Followers() ([]Follower, error) {
+	var followers []Follower
+	if err := s.db.Find(&followers).Error; err != nil {
+		return nil, err
+	}
+	return followers, nil
+}
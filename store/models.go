@@ -0,0 +1,25 @@
+package store
+
+import "time"
+
+// Summary records that SourceStatusID was already summarized by
+// ReplyStatusID, so the bot never summarizes the same status twice.
+type Summary struct {
+	SourceStatusID string `gorm:"primaryKey"`
+	ReplyStatusID  string
+	CreatedAt      time.Time
+}
+
+// OptOut records an account that has asked not to get mention replies or
+// auto-TL;DRs.
+type OptOut struct {
+	Acct      string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+// Follower records an account that currently follows the bot.
+type Follower struct {
+	AccountID string `gorm:"primaryKey"`
+	Acct      string
+	CreatedAt time.Time
+}
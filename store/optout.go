@@ -0,0 +1,18 @@
+package store
+
+import "gorm.io/gorm/clause"
+
+// IsOptedOut reports whether acct has opted out of mention replies and
+// auto-TL;DRs.
+func (s *Store) IsOptedOut(acct string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&OptOut{}).Where("acct = ?", acct).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// OptOut records that acct no longer wants mention replies or auto-TL;DRs.
+func (s *Store) OptOut(acct string) error {
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&OptOut{Acct: acct}).Error
+}
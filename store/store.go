@@ -0,0 +1,42 @@
+// Package store provides the bot's persistent state: which statuses it has
+// already replied to, which accounts have opted out, and who currently
+// follows it. It is backed by SQLite via GORM so the bot doesn't
+// double-summarize, re-follow, or re-message anyone across restarts.
+package store
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Store wraps the SQLite-backed persistence layer.
+type Store struct {
+	db *gorm.DB
+}
+
+// Open opens (creating if necessary) the SQLite database at path and
+// migrates its schema.
+func Open(path string) (*Store, error) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&Summary{}, &OptOut{}, &Follower{}); err != nil {
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database connection.
+func (s *Store) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSummaryDedupAndLookup(t *testing.T) {
+	s := openTestStore(t)
+
+	done, err := s.HasSummarized("status-1")
+	if err != nil {
+		t.Fatalf("HasSummarized: %v", err)
+	}
+	if done {
+		t.Fatal("expected status-1 to not be summarized yet")
+	}
+
+	if err := s.RecordSummary("status-1", "reply-1"); err != nil {
+		t.Fatalf("RecordSummary: %v", err)
+	}
+
+	done, err = s.HasSummarized("status-1")
+	if err != nil {
+		t.Fatalf("HasSummarized: %v", err)
+	}
+	if !done {
+		t.Fatal("expected status-1 to be summarized after RecordSummary")
+	}
+
+	replyID, ok, err := s.GetSummaryReply("status-1")
+	if err != nil {
+		t.Fatalf("GetSummaryReply: %v", err)
+	}
+	if !ok || replyID != "reply-1" {
+		t.Fatalf("GetSummaryReply = %q, %v, want reply-1, true", replyID, ok)
+	}
+
+	if err := s.DeleteSummary("status-1"); err != nil {
+		t.Fatalf("DeleteSummary: %v", err)
+	}
+	if _, ok, err := s.GetSummaryReply("status-1"); err != nil || ok {
+		t.Fatalf("GetSummaryReply after delete = ok=%v err=%v, want ok=false", ok, err)
+	}
+}
+
+func TestGetSummaryReplyUnknownStatus(t *testing.T) {
+	s := openTestStore(t)
+
+	if _, ok, err := s.GetSummaryReply("missing"); err != nil || ok {
+		t.Fatalf("GetSummaryReply(missing) = ok=%v err=%v, want ok=false, err=nil", ok, err)
+	}
+}
+
+func TestOptOut(t *testing.T) {
+	s := openTestStore(t)
+
+	optedOut, err := s.IsOptedOut("alice@example.social")
+	if err != nil {
+		t.Fatalf("IsOptedOut: %v", err)
+	}
+	if optedOut {
+		t.Fatal("expected alice to not be opted out yet")
+	}
+
+	if err := s.OptOut("alice@example.social"); err != nil {
+		t.Fatalf("OptOut: %v", err)
+	}
+	// Opting out twice should not error (DoNothing on conflict).
+	if err := s.OptOut("alice@example.social"); err != nil {
+		t.Fatalf("second OptOut: %v", err)
+	}
+
+	optedOut, err = s.IsOptedOut("alice@example.social")
+	if err != nil {
+		t.Fatalf("IsOptedOut: %v", err)
+	}
+	if !optedOut {
+		t.Fatal("expected alice to be opted out after OptOut")
+	}
+}
+
+func TestFollowerLifecycle(t *testing.T) {
+	s := openTestStore(t)
+
+	following, err := s.IsFollowing("123")
+	if err != nil {
+		t.Fatalf("IsFollowing: %v", err)
+	}
+	if following {
+		t.Fatal("expected account 123 to not be a follower yet")
+	}
+
+	if err := s.RecordFollow("123", "bob@example.social"); err != nil {
+		t.Fatalf("RecordFollow: %v", err)
+	}
+	// Recording the same follower twice should not error.
+	if err := s.RecordFollow("123", "bob@example.social"); err != nil {
+		t.Fatalf("second RecordFollow: %v", err)
+	}
+
+	following, err = s.IsFollowing("123")
+	if err != nil {
+		t.Fatalf("IsFollowing: %v", err)
+	}
+	if !following {
+		t.Fatal("expected account 123 to be a follower after RecordFollow")
+	}
+
+	followers, err := s.Followers()
+	if err != nil {
+		t.Fatalf("Followers: %v", err)
+	}
+	if len(followers) != 1 || followers[0].AccountID != "123" {
+		t.Fatalf("Followers = %+v, want a single entry for account 123", followers)
+	}
+
+	if err := s.RecordUnfollow("123"); err != nil {
+		t.Fatalf("RecordUnfollow: %v", err)
+	}
+
+	following, err = s.IsFollowing("123")
+	if err != nil {
+		t.Fatalf("IsFollowing: %v", err)
+	}
+	if following {
+		t.Fatal("expected account 123 to not be a follower after RecordUnfollow")
+	}
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// HasSummarized reports whether sourceStatusID has already been summarized.
+func (s *Store) HasSummarized(sourceStatusID string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&Summary{}).Where("source_status_id = ?", sourceStatusID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RecordSummary remembers that sourceStatusID was summarized by replyStatusID.
+func (s *Store) RecordSummary(sourceStatusID, replyStatusID string) error {
+	return s.db.Create(&Summary{SourceStatusID: sourceStatusID, ReplyStatusID: replyStatusID}).Error
+}
+
+// GetSummaryReply returns the reply status ID previously recorded for
+// sourceStatusID, if any.
+func (s *Store) GetSummaryReply(sourceStatusID string) (string, bool, error) {
+	var summary Summary
+	err := s.db.Where("source_status_id = ?", sourceStatusID).First(&summary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return summary.ReplyStatusID, true, nil
+}
+
+// DeleteSummary removes the stored summary record for sourceStatusID, e.g.
+// after its TL;DR reply has been deleted.
+func (s *Store) DeleteSummary(sourceStatusID string) error {
+	return s.db.Where("source_status_id = ?", sourceStatusID).Delete(&Summary{}).Error
+}